@@ -3,10 +3,13 @@ package engine
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/sha256"
+	"crypto/x509"
 	_ "embed"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,9 +17,13 @@ import (
 	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/fulcio/pkg/fulcioroots"
 	"github.com/sigstore/k8s-manifest-sigstore/pkg/k8smanifest"
 	k8smnfutil "github.com/sigstore/k8s-manifest-sigstore/pkg/util"
 	mapnode "github.com/sigstore/k8s-manifest-sigstore/pkg/util/mapnode"
+	"github.com/sigstore/rekor/pkg/client"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -29,24 +36,475 @@ import (
 const DefaultAnnotationKeyDomain = "cosign.sigstore.dev/"
 const defaultDryRunNamespace = "kyverno"
 
+const (
+	signatureAnnotationKey = DefaultAnnotationKeyDomain + "signature"
+	messageAnnotationKey   = DefaultAnnotationKeyDomain + "message"
+	imageRefAnnotationKey  = DefaultAnnotationKeyDomain + imageRefAnnotationKeySuffix
+)
+
+// ManifestAnnotationKeyDomain is the prefix for per-resource overrides of the manifest
+// verification behaviour, borrowed from the compare/sync-options annotations Argo CD exposes
+// on GitOps-managed resources (argocd.argoproj.io/compare-options, sync-options).
+const ManifestAnnotationKeyDomain = "manifest.kyverno.io/"
+
+// serverSideInjectedFields are commonly mutated by the API server or controllers after
+// admission. A resource can ignore all of them at once via the IgnoreExtraneous compare
+// option instead of listing each one in its ignoreFields annotation.
+var serverSideInjectedFields = []string{
+	"spec.clusterIP",
+	"spec.clusterIPs",
+	"spec.finalizers",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.uid",
+	"metadata.creationTimestamp",
+	"metadata.selfLink",
+	"status",
+}
+
 // This is common ignore fields for changes by k8s system
 //go:embed resources/default-config.yaml
 var defaultConfigBytes []byte
 
-func VerifyManifest(policyContext *PolicyContext, ecdsaPub string, ignoreFields k8smanifest.ObjectFieldBindingList, dryRun bool) (bool, *mapnode.DiffResult, error) {
+// imagePullSecretsGetter resolves credentials for OCI-registry-hosted manifest bundles.
+// Nil means the registry is pulled anonymously. Set by SetImagePullSecretsGetter during
+// Kyverno startup, once the configuration controller has loaded imagePullSecrets.
+var imagePullSecretsGetter ImagePullSecretsGetter
+
+// SetImagePullSecretsGetter configures how VerifyManifest resolves credentials when a
+// resource's manifest bundle is hosted as an OCI image rather than embedded in an annotation.
+func SetImagePullSecretsGetter(getter ImagePullSecretsGetter) {
+	imagePullSecretsGetter = getter
+}
+
+// VerifyManifestResult is the outcome of a manifest signature verification. Signer attributes
+// the result to whichever trusted key or certificate identity approved the manifest, so policy
+// reports can say who signed it rather than just pass/fail.
+type VerifyManifestResult struct {
+	Verified bool
+	Diff     *mapnode.DiffResult
+	Signer   string
+}
+
+// TrustPolicy is the set of keys VerifyManifest accepts a signature from. A signature is
+// accepted if it validates against any key in Keys that isn't revoked and whose validity
+// window (if any) covers the time of verification.
+type TrustPolicy struct {
+	Keys []TrustedKey
+	// RevokedFingerprints excludes keys from Keys by the SHA256 fingerprint of their DER
+	// encoding (see keyFingerprint), so a compromised signer can be revoked without editing
+	// or removing its PEM from Keys.
+	RevokedFingerprints []string
+}
+
+// TrustedKey is one ECDSA public key in a TrustPolicy. NotBefore/NotAfter let a key be rotated
+// in and out of trust over time instead of trusting it indefinitely.
+type TrustedKey struct {
+	PEM       string
+	NotBefore *time.Time
+	NotAfter  *time.Time
+}
+
+// VerifyManifest verifies the manifest embedded in the admission request against trustPolicy.
+// Results are served from an in-process cache keyed by the object, its signature and
+// trustPolicy, unless skipCache is set (tests use this to bypass it). Verdicts for a policy
+// whose keys carry a NotBefore/NotAfter window are not stored in the cache.
+func VerifyManifest(policyContext *PolicyContext, trustPolicy TrustPolicy, ignoreFields k8smanifest.ObjectFieldBindingList, decryption DecryptionPolicy, dryRun, skipCache bool) (*VerifyManifestResult, error) {
+	dryRunAdmission, err := isAdmissionDryRun(policyContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheKey string
+	if !skipCache && !dryRunAdmission {
+		if key, ok := manifestVerifyCacheKey(policyContext, trustPolicyHash(trustPolicy), ignoreFields, dryRun); ok {
+			if entry, hit := lookupVerifyCache(key); hit {
+				return &VerifyManifestResult{Verified: entry.verified, Diff: entry.diff, Signer: entry.signer}, nil
+			}
+			cacheKey = key
+		}
+	}
+
+	mr, skip, err := prepareManifestVerification(policyContext, ignoreFields, dryRun, decryption)
+	if err != nil {
+		return nil, err
+	}
+	if skip {
+		return &VerifyManifestResult{Verified: true}, nil
+	}
+
+	revoked := make(map[string]bool, len(trustPolicy.RevokedFingerprints))
+	for _, fp := range trustPolicy.RevokedFingerprints {
+		revoked[fp] = true
+	}
+
+	digest := sha256.Sum256(mr.message)
+	signer, hasRotationWindow, err := selectTrustedSigner(trustPolicy, revoked, digest, mr.sig, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyManifestResult{Verified: false, Diff: mr.diff}
+	if signer != "" {
+		result = &VerifyManifestResult{Verified: mr.mnfMatched, Diff: mr.diff, Signer: signer}
+	}
+
+	if cacheKey != "" && !hasRotationWindow {
+		storeVerifyCache(cacheKey, verifyCacheEntry{verified: result.Verified, diff: result.Diff, signer: result.Signer})
+	}
+	return result, nil
+}
+
+// selectTrustedSigner walks trustPolicy.Keys for the first one whose ECDSA signature over
+// digest matches sig, is not in revoked, and is within its NotBefore/NotAfter rotation window
+// as of now. It returns that key's fingerprint as signer, or "" if none match. hasRotationWindow
+// is true when any key examined up to and including the returned one (every key, when none
+// matches) carries a NotBefore/NotAfter - a cached verdict involving
+// such a key would otherwise keep being served, unchanged, right up until the cache TTL expires,
+// even after NotAfter has elapsed wall-clock time in between, so the caller must not cache it.
+func selectTrustedSigner(trustPolicy TrustPolicy, revoked map[string]bool, digest [sha256.Size]byte, sig []byte, now time.Time) (signer string, hasRotationWindow bool, err error) {
+	for _, key := range trustPolicy.Keys {
+		if key.NotBefore != nil || key.NotAfter != nil {
+			hasRotationWindow = true
+		}
+
+		publicKey, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(key.PEM))
+		if err != nil {
+			log.Debug("skipping unparseable trusted key: ", err)
+			continue
+		}
+		ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			log.Debug("skipping non-ECDSA trusted key")
+			continue
+		}
+		if !ecdsa.VerifyASN1(ecdsaKey, digest[:], sig) {
+			continue
+		}
+
+		fp, err := keyFingerprint(ecdsaKey)
+		if err != nil {
+			return "", hasRotationWindow, fmt.Errorf("failed to compute trusted key fingerprint: %v", err)
+		}
+		if revoked[fp] {
+			continue
+		}
+		// The Rekor-logged inclusion time isn't available for raw-key signatures, so the
+		// key's rotation window is checked against the current time instead.
+		if key.NotBefore != nil && now.Before(*key.NotBefore) {
+			continue
+		}
+		if key.NotAfter != nil && now.After(*key.NotAfter) {
+			continue
+		}
+
+		return fp, hasRotationWindow, nil
+	}
+	return "", hasRotationWindow, nil
+}
+
+// keyFingerprint is the hex-encoded SHA256 digest of pub's DER encoding, used to identify a
+// TrustedKey in RevokedFingerprints and in VerifyManifestResult.Signer.
+func keyFingerprint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// KeylessPolicy configures Fulcio/Rekor based keyless verification for VerifyManifestKeyless.
+// A signature is accepted only when its certificate chains to the Fulcio root, its Rekor
+// transparency log entry is present and its signed entry timestamp validates, and the
+// certificate's identity matches at least one entry in Identities.
+type KeylessPolicy struct {
+	// RekorURL is the address of the Rekor transparency log used to verify inclusion of the signature.
+	RekorURL string
+	// FulcioURL is the address of the Fulcio CA that issued the signing certificate.
+	// VerifyManifestKeyless does not read it: the chain is checked against fulcioroots.Get().
+	FulcioURL string
+	// Identities lists the certificate identities allowed to sign the manifest.
+	Identities []Identity
+}
+
+// Identity constrains the SAN and OIDC issuer embedded in a Fulcio-issued certificate.
+// Either the exact or the regexp variant of a field may be set.
+type Identity struct {
+	CertIdentity         string
+	CertIdentityRegExp   string
+	CertOIDCIssuer       string
+	CertOIDCIssuerRegExp string
+}
+
+// IdentityMismatchErr is returned when a keyless signature is cryptographically valid and
+// logged in Rekor, but the signing certificate's identity doesn't match any entry in the
+// KeylessPolicy. Callers can distinguish this from a cryptographic/transparency-log failure
+// with errors.As.
+type IdentityMismatchErr struct {
+	Issuer  string
+	Subject string
+}
+
+func (e *IdentityMismatchErr) Error() string {
+	return fmt.Sprintf("certificate identity %q issued by %q does not match any allowed identity", e.Subject, e.Issuer)
+}
+
+// VerifyManifestKeyless verifies the manifest embedded in the admission request the same way
+// VerifyManifest does, but validates the signature against a Fulcio-issued short-lived
+// certificate and its Rekor transparency log entry instead of a long-lived ECDSA key. Like
+// VerifyManifest, results are served from the in-process cache unless skipCache is set.
+func VerifyManifestKeyless(policyContext *PolicyContext, keylessPolicy KeylessPolicy, ignoreFields k8smanifest.ObjectFieldBindingList, decryption DecryptionPolicy, dryRun, skipCache bool) (*VerifyManifestResult, error) {
+	dryRunAdmission, err := isAdmissionDryRun(policyContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheKey string
+	if !skipCache && !dryRunAdmission {
+		if key, ok := manifestVerifyCacheKey(policyContext, keylessPolicyHash(keylessPolicy), ignoreFields, dryRun); ok {
+			if entry, hit := lookupVerifyCache(key); hit {
+				return &VerifyManifestResult{Verified: entry.verified, Diff: entry.diff, Signer: entry.signer}, nil
+			}
+			cacheKey = key
+		}
+	}
+
+	mr, skip, err := prepareManifestVerification(policyContext, ignoreFields, dryRun, decryption)
+	if err != nil {
+		return nil, err
+	}
+	if skip {
+		return &VerifyManifestResult{Verified: true}, nil
+	}
+
+	certAnnotationKey := DefaultAnnotationKeyDomain + "certificate"
+	bundleAnnotationKey := DefaultAnnotationKeyDomain + "bundle"
+
+	certPEM, _ := base64.StdEncoding.DecodeString(mr.annotation[certAnnotationKey])
+	if len(certPEM) == 0 {
+		return nil, fmt.Errorf("%s annotation not found, keyless verification requires a certificate", certAnnotationKey)
+	}
+	rawBundle, _ := base64.StdEncoding.DecodeString(mr.annotation[bundleAnnotationKey])
+	if len(rawBundle) == 0 {
+		return nil, fmt.Errorf("%s annotation not found, keyless verification requires a Rekor bundle", bundleAnnotationKey)
+	}
+
+	cert, err := cryptoutils.UnmarshalCertificatesFromPEM(certPEM)
+	if err != nil || len(cert) == 0 {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	leafCert := cert[0]
+
+	roots, err := fulcioroots.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fulcio root certificates: %v", err)
+	}
+	intermediates, err := fulcioroots.GetIntermediates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fulcio intermediate certificates: %v", err)
+	}
+	var rekorBundle bundle.RekorBundle
+	if err := json.Unmarshal(rawBundle, &rekorBundle); err != nil {
+		return nil, fmt.Errorf("failed to parse rekor bundle: %v", err)
+	}
+	rekorClient, err := client.GetRekorClient(keylessPolicy.RekorURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rekor client: %v", err)
+	}
+	rekorPub, err := cosign.GetRekorPub(rekorClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rekor public key: %v", err)
+	}
+	payload, err := json.Marshal(rekorBundle.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rekor bundle payload: %v", err)
+	}
+	if err := cosign.VerifySET(payload, []byte(rekorBundle.SignedEntryTimestamp), rekorPub); err != nil {
+		return nil, fmt.Errorf("rekor signed entry timestamp verification failed: %v", err)
+	}
+
+	signingTime := time.Unix(rekorBundle.Payload.IntegratedTime, 0)
+	if err := verifyFulcioCertChain(leafCert, roots, intermediates, signingTime); err != nil {
+		return nil, err
+	}
+
+	leafKey, ok := leafCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported certificate public key type %T", leafCert.PublicKey)
+	}
+	digest := sha256.Sum256(mr.message)
+	if !ecdsa.VerifyASN1(leafKey, digest[:], mr.sig) {
+		result := &VerifyManifestResult{Verified: false, Diff: mr.diff}
+		if cacheKey != "" {
+			storeVerifyCache(cacheKey, verifyCacheEntry{verified: result.Verified, diff: result.Diff})
+		}
+		return result, nil
+	}
+
+	issuer := certOIDCIssuer(leafCert)
+	if !matchesAnyIdentity(leafCert, issuer, keylessPolicy.Identities) {
+		// Identity mismatches aren't cached: a cache entry holds only a verdict, so a hit could
+		// not return this distinct error.
+		return nil, &IdentityMismatchErr{Issuer: issuer, Subject: certIdentity(leafCert)}
+	}
+
+	result := &VerifyManifestResult{Verified: mr.mnfMatched, Diff: mr.diff, Signer: certIdentity(leafCert)}
+	if cacheKey != "" {
+		storeVerifyCache(cacheKey, verifyCacheEntry{verified: result.Verified, diff: result.Diff, signer: result.Signer})
+	}
+	return result, nil
+}
+
+// verifyFulcioCertChain checks that leafCert chains to roots/intermediates as of signingTime
+// rather than the current time - the leaf cert is only valid for the ~10 minute window Fulcio
+// issued it in, so verifying as of now would reject every admission review of a resource that's
+// re-applied after that window elapses.
+func verifyFulcioCertChain(leafCert *x509.Certificate, roots, intermediates *x509.CertPool, signingTime time.Time) error {
+	if _, err := leafCert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		CurrentTime:   signingTime,
+	}); err != nil {
+		return fmt.Errorf("certificate does not chain to a trusted fulcio root: %v", err)
+	}
+	return nil
+}
+
+func certIdentity(cert *x509.Certificate) string {
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	return cert.Subject.CommonName
+}
+
+func certOIDCIssuer(cert *x509.Certificate) string {
+	// Fulcio embeds the OIDC issuer as a custom extension (OID 1.3.6.1.4.1.57264.1.1).
+	for _, ext := range cert.Extensions {
+		if ext.Id.String() == "1.3.6.1.4.1.57264.1.1" {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+func matchesAnyIdentity(cert *x509.Certificate, issuer string, identities []Identity) bool {
+	subject := certIdentity(cert)
+	for _, id := range identities {
+		if id.CertIdentity != "" && id.CertIdentity != subject {
+			continue
+		}
+		if id.CertIdentityRegExp != "" && !k8smnfutil.MatchPattern(id.CertIdentityRegExp, subject) {
+			continue
+		}
+		if id.CertOIDCIssuer != "" && id.CertOIDCIssuer != issuer {
+			continue
+		}
+		if id.CertOIDCIssuerRegExp != "" && !k8smnfutil.MatchPattern(id.CertOIDCIssuerRegExp, issuer) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// manifestRequest carries the outcome of extracting and matching the admission request's
+// object against the signed manifest, shared by VerifyManifest and VerifyManifestKeyless.
+type manifestRequest struct {
+	annotation map[string]string
+	sig        []byte
+	message    []byte
+	mnfMatched bool
+	diff       *mapnode.DiffResult
+}
+
+// resourceIgnoreOptions are the per-resource overrides parsed from manifest.kyverno.io
+// annotations on the resource being admitted. They are the last and highest-precedence source
+// in the ignoreFields chain: cluster-wide default-config.yaml is applied first, then the
+// caller-supplied ignoreFields, then these annotations, so a field listed here is ignored even
+// if the other two sources don't mention it.
+type resourceIgnoreOptions struct {
+	ignoreFields     []string
+	skipDryRunCreate bool
+	skipDryRunApply  bool
+}
+
+// parseResourceIgnoreOptions reads the manifest.kyverno.io/ignoreFields and
+// manifest.kyverno.io/compareOptions annotations off the resource. ignoreFields is a
+// comma-separated list of dot-separated field paths. compareOptions is a comma-separated list
+// of: IgnoreExtraneous (also ignore serverSideInjectedFields), IgnoreDryRunCreate and
+// IgnoreDryRunApply (skip the corresponding matching strategy for this resource).
+func parseResourceIgnoreOptions(annotation map[string]string) resourceIgnoreOptions {
+	var opt resourceIgnoreOptions
+
+	if raw := annotation[ManifestAnnotationKeyDomain+"ignoreFields"]; raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opt.ignoreFields = append(opt.ignoreFields, field)
+			}
+		}
+	}
+
+	if raw := annotation[ManifestAnnotationKeyDomain+"compareOptions"]; raw != "" {
+		for _, option := range strings.Split(raw, ",") {
+			switch strings.TrimSpace(option) {
+			case "IgnoreExtraneous":
+				opt.ignoreFields = append(opt.ignoreFields, serverSideInjectedFields...)
+			case "IgnoreDryRunCreate":
+				opt.skipDryRunCreate = true
+			case "IgnoreDryRunApply":
+				opt.skipDryRunApply = true
+			default:
+				log.Debug("ignoring unknown compareOptions value", option)
+			}
+		}
+	}
+
+	return opt
+}
+
+// admissionRequest extracts the raw AdmissionRequest out of the policy context's JSON context.
+func admissionRequest(policyContext *PolicyContext) (*v1beta1.AdmissionRequest, error) {
 	request, err := policyContext.JSONContext.Query("request")
 	if err != nil {
-		return false, nil, err
+		return nil, err
 	}
 	reqByte, _ := json.Marshal(request)
 	var adreq *v1beta1.AdmissionRequest
-	err = json.Unmarshal(reqByte, &adreq)
+	if err := json.Unmarshal(reqByte, &adreq); err != nil {
+		return nil, err
+	}
+	return adreq, nil
+}
+
+// isAdmissionDryRun reports whether the admission request itself is a dry-run (e.g. `kubectl
+// apply --dry-run=server`), in which case VerifyManifest/VerifyManifestKeyless admit
+// unconditionally without verifying, cache included - a dry-run never mutates cluster state, so
+// there's nothing for the cache to get right or wrong on its behalf.
+func isAdmissionDryRun(policyContext *PolicyContext) (bool, error) {
+	adreq, err := admissionRequest(policyContext)
+	if err != nil {
+		return false, err
+	}
+	return adreq.DryRun != nil && *adreq.DryRun, nil
+}
+
+// prepareManifestVerification extracts the admitted object and its signed manifest from the
+// policy context, runs the dryrun/direct/apply matching pipeline, and returns the shared state
+// needed to finish either ECDSA or keyless signature verification. skip is true when the
+// admission request is itself a dryrun, in which case the caller should admit without verifying.
+func prepareManifestVerification(policyContext *PolicyContext, ignoreFields k8smanifest.ObjectFieldBindingList, dryRun bool, decryption DecryptionPolicy) (*manifestRequest, bool, error) {
+	adreq, err := admissionRequest(policyContext)
 	if err != nil {
-		return false, nil, err
+		return nil, false, err
 	}
 
 	if *adreq.DryRun {
-		return true, nil, nil
+		return nil, true, nil
 	}
 
 	vo := &k8smanifest.VerifyResourceOption{}
@@ -60,37 +518,53 @@ func VerifyManifest(policyContext *PolicyContext, ecdsaPub string, ignoreFields
 	var obj unstructured.Unstructured
 	err = yaml.Unmarshal(objManifest, &obj)
 	if err != nil {
-		return false, nil, err
+		return nil, false, err
 	}
 	annotation := policyContext.NewResource.GetAnnotations()
-	signatureAnnotationKey := DefaultAnnotationKeyDomain + "signature"
-	messageAnnotationKey := DefaultAnnotationKeyDomain + "message"
 
-	sig, _ := base64.StdEncoding.DecodeString(annotation[signatureAnnotationKey])
+	var sig, message, foundManifest []byte
+	if imageRef := annotation[imageRefAnnotationKey]; imageRef != "" {
+		// The manifest tarball is too large to stuff into an annotation, so the resource
+		// instead points at an OCI image carrying the signed manifest and its signature.
+		foundManifest, sig, err = fetchManifestBundleFromImage(imageRef, imagePullSecretsGetter)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to fetch manifest bundle from %s: %v", imageRef, err)
+		}
+		foundManifest, err = decryptEnvelope(foundManifest, decryption)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decrypt manifest bundle from %s: %v", imageRef, err)
+		}
+		message = foundManifest
+	} else {
+		sig, _ = base64.StdEncoding.DecodeString(annotation[signatureAnnotationKey])
 
-	gzipMsg, _ := base64.StdEncoding.DecodeString(annotation[messageAnnotationKey])
-	// `gzipMsg` is a gzip compressed .tar.gz file, so getting a tar ball by decompressing it.
-	message := k8smnfutil.GzipDecompress(gzipMsg)
-	byteStream := bytes.NewBuffer(message)
-	uncompressedStream, err := gzip.NewReader(byteStream)
-	if err != nil {
-		return false, nil, fmt.Errorf("unzip err: %v\n", err)
-	}
-	defer uncompressedStream.Close()
+		gzipMsg, _ := base64.StdEncoding.DecodeString(annotation[messageAnnotationKey])
+		gzipMsg, err = decryptEnvelope(gzipMsg, decryption)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decrypt %s annotation: %v", messageAnnotationKey, err)
+		}
+		// `gzipMsg` is a gzip compressed .tar.gz file, so getting a tar ball by decompressing it.
+		message = k8smnfutil.GzipDecompress(gzipMsg)
+		byteStream := bytes.NewBuffer(message)
+		uncompressedStream, err := gzip.NewReader(byteStream)
+		if err != nil {
+			return nil, false, fmt.Errorf("unzip err: %v\n", err)
+		}
+		defer uncompressedStream.Close()
 
-	// reading a tar ball, in-memory.
-	byteSlice, err := ioutil.ReadAll(uncompressedStream)
-	if err != nil {
-		return false, nil, fmt.Errorf("read err :%v", err)
-	}
-	i := strings.Index(string(byteSlice), "apiVersion")
-	byteSlice = byteSlice[i:]
-	var foundManifest []byte
-	for _, ch := range byteSlice {
-		if ch != 0 {
-			foundManifest = append(foundManifest, ch)
-		} else {
-			break
+		// reading a tar ball, in-memory.
+		byteSlice, err := ioutil.ReadAll(uncompressedStream)
+		if err != nil {
+			return nil, false, fmt.Errorf("read err :%v", err)
+		}
+		i := strings.Index(string(byteSlice), "apiVersion")
+		byteSlice = byteSlice[i:]
+		for _, ch := range byteSlice {
+			if ch != 0 {
+				foundManifest = append(foundManifest, ch)
+			} else {
+				break
+			}
 		}
 	}
 
@@ -104,12 +578,20 @@ func VerifyManifest(policyContext *PolicyContext, ecdsaPub string, ignoreFields
 		}
 	}
 
+	// The resource itself can add to (never remove from) the above precedence chain via
+	// manifest.kyverno.io annotations, e.g. to ignore a field this cluster-wide config and the
+	// caller don't know about, or to opt out of the more expensive dryrun-apply strategy.
+	resourceOpt := parseResourceIgnoreOptions(annotation)
+	ignore = append(ignore, resourceOpt.ignoreFields...)
+	checkDryRunForCreate := dryRun && !resourceOpt.skipDryRunCreate
+	checkDryRunForApply := dryRun && !resourceOpt.skipDryRunApply
+
 	var mnfMatched bool
 	var diff *mapnode.DiffResult
 	var diffsForAllCandidates []*mapnode.DiffResult
-	cndMatched, tmpDiff, err := matchResourceWithManifest(obj, foundManifest, ignore, "", dryRun, dryRun)
+	cndMatched, tmpDiff, err := matchResourceWithManifest(obj, foundManifest, ignore, "", checkDryRunForCreate, checkDryRunForApply)
 	if err != nil {
-		return false, nil, fmt.Errorf("error occurred during matching manifest: %v", err)
+		return nil, false, fmt.Errorf("error occurred during matching manifest: %v", err)
 	}
 	diffsForAllCandidates = append(diffsForAllCandidates, tmpDiff)
 	if cndMatched {
@@ -119,17 +601,13 @@ func VerifyManifest(policyContext *PolicyContext, ecdsaPub string, ignoreFields
 		diff = diffsForAllCandidates[0]
 	}
 
-	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(ecdsaPub))
-	if err != nil {
-		return false, nil, fmt.Errorf("unexpected error unmarshalling public key: %v", err)
-	}
-
-	digest := sha256.Sum256(message)
-	// verifying message and signature for the supplied key.
-	sigVerified := ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest[:], sig)
-
-	verified := mnfMatched && sigVerified
-	return verified, diff, nil
+	return &manifestRequest{
+		annotation: annotation,
+		sig:        sig,
+		message:    message,
+		mnfMatched: mnfMatched,
+		diff:       diff,
+	}, false, nil
 }
 
 func matchResourceWithManifest(obj unstructured.Unstructured, foundManifestBytes []byte, ignoreFields []string, dryRunNamespace string, checkDryRunForCreate, checkDryRunForApply bool) (bool, *mapnode.DiffResult, error) {
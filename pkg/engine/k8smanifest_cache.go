@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/sigstore/k8s-manifest-sigstore/pkg/k8smanifest"
+	mapnode "github.com/sigstore/k8s-manifest-sigstore/pkg/util/mapnode"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	defaultVerifyCacheSize = 1024
+	defaultVerifyCacheTTL  = 5 * time.Minute
+)
+
+// CacheOptions configures the in-process cache of manifest verification results shared by
+// VerifyManifest and VerifyManifestKeyless.
+type CacheOptions struct {
+	// Size is the maximum number of cached results. Zero uses defaultVerifyCacheSize.
+	Size int
+	// TTL is how long a cached result stays valid. Zero uses defaultVerifyCacheTTL.
+	TTL time.Duration
+}
+
+// verifyCacheEntry is everything a cache hit needs to reconstruct a VerifyManifestResult
+// without repeating the decompression, tar parsing and signature/dryrun-apply work.
+type verifyCacheEntry struct {
+	verified bool
+	diff     *mapnode.DiffResult
+	signer   string
+}
+
+var (
+	verifyCacheMu = sync.Mutex{}
+	verifyCache   = lru.NewLRU[string, verifyCacheEntry](defaultVerifyCacheSize, onVerifyCacheEvict, defaultVerifyCacheTTL)
+)
+
+var (
+	verifyCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kyverno_manifest_verify_cache_hits_total",
+		Help: "Number of manifest verification requests served from the in-process cache.",
+	})
+	verifyCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kyverno_manifest_verify_cache_misses_total",
+		Help: "Number of manifest verification requests that missed the in-process cache.",
+	})
+	verifyCacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kyverno_manifest_verify_cache_evictions_total",
+		Help: "Number of entries evicted from the manifest verification cache, by capacity or TTL.",
+	})
+)
+
+func onVerifyCacheEvict(_ string, _ verifyCacheEntry) {
+	verifyCacheEvictions.Inc()
+}
+
+// SetVerifyCacheOptions reconfigures the size and TTL of the manifest verification cache.
+// Existing entries are discarded: they were sized and aged for the previous configuration.
+func SetVerifyCacheOptions(opts CacheOptions) {
+	size := opts.Size
+	if size <= 0 {
+		size = defaultVerifyCacheSize
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = defaultVerifyCacheTTL
+	}
+
+	verifyCacheMu.Lock()
+	defer verifyCacheMu.Unlock()
+	verifyCache = lru.NewLRU[string, verifyCacheEntry](size, onVerifyCacheEvict, ttl)
+}
+
+func lookupVerifyCache(key string) (verifyCacheEntry, bool) {
+	verifyCacheMu.Lock()
+	defer verifyCacheMu.Unlock()
+	entry, ok := verifyCache.Get(key)
+	if ok {
+		verifyCacheHits.Inc()
+	} else {
+		verifyCacheMisses.Inc()
+	}
+	return entry, ok
+}
+
+func storeVerifyCache(key string, entry verifyCacheEntry) {
+	verifyCacheMu.Lock()
+	defer verifyCacheMu.Unlock()
+	verifyCache.Add(key, entry)
+}
+
+// trustPolicyHash fingerprints a TrustPolicy so the verification cache key changes whenever
+// the trusted keys, their validity windows, or the revocation list change - an outdated cache
+// entry computed under a previous TrustPolicy is never looked up again.
+func trustPolicyHash(tp TrustPolicy) string {
+	return hashJSON(tp)
+}
+
+// keylessPolicyHash fingerprints a KeylessPolicy the same way trustPolicyHash does for TrustPolicy.
+func keylessPolicyHash(kp KeylessPolicy) string {
+	return hashJSON(kp)
+}
+
+func hashJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Debug("failed to hash verification policy, caching disabled for this request: ", err)
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestVerifyCacheKey derives the cache key for a manifest verification request:
+// sha256(objectBytes || signatureBytes || policyHash || ignoreFields || dryRun). objectBytes
+// and the raw annotation values are read directly from the admission request, before any
+// decompression or dryrun-apply work, so a cache hit skips all of it. ignoreFields and dryRun
+// are folded in too: two rules can share a TrustPolicy/KeylessPolicy but disagree on either,
+// and must not read back each other's verdict for the same object/signature. Returns ok=false
+// when the request isn't cacheable (e.g. its own policy couldn't be hashed).
+func manifestVerifyCacheKey(policyContext *PolicyContext, policyHash string, ignoreFields k8smanifest.ObjectFieldBindingList, dryRun bool) (string, bool) {
+	if policyHash == "" {
+		return "", false
+	}
+
+	adreq, err := admissionRequest(policyContext)
+	if err != nil {
+		return "", false
+	}
+
+	ignoreFieldsHash := hashJSON(ignoreFields)
+	if ignoreFieldsHash == "" && len(ignoreFields) > 0 {
+		return "", false
+	}
+
+	annotation := policyContext.NewResource.GetAnnotations()
+	// Fixed order: signature, message, imageRef, certificate, bundle - whichever of these the
+	// configured verification mode actually uses, the rest are empty strings and contribute
+	// nothing to the digest.
+	sigMaterial := []string{
+		annotation[signatureAnnotationKey],
+		annotation[messageAnnotationKey],
+		annotation[imageRefAnnotationKey],
+		annotation[DefaultAnnotationKeyDomain+"certificate"],
+		annotation[DefaultAnnotationKeyDomain+"bundle"],
+	}
+
+	h := sha256.New()
+	h.Write(adreq.Object.Raw)
+	for _, s := range sigMaterial {
+		h.Write([]byte(s))
+	}
+	h.Write([]byte(policyHash))
+	h.Write([]byte(ignoreFieldsHash))
+	if dryRun {
+		h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
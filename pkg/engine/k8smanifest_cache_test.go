@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrustPolicyHashChangesWithKeys(t *testing.T) {
+	a := TrustPolicy{Keys: []TrustedKey{{PEM: "key-a"}}}
+	b := TrustPolicy{Keys: []TrustedKey{{PEM: "key-b"}}}
+
+	if trustPolicyHash(a) == trustPolicyHash(b) {
+		t.Errorf("trustPolicyHash should differ for different trusted keys")
+	}
+	if trustPolicyHash(a) != trustPolicyHash(a) {
+		t.Errorf("trustPolicyHash should be stable for the same TrustPolicy")
+	}
+}
+
+func TestTrustPolicyHashChangesWithRotationWindow(t *testing.T) {
+	notAfter := time.Unix(0, 0)
+	withWindow := TrustPolicy{Keys: []TrustedKey{{PEM: "key-a", NotAfter: &notAfter}}}
+	withoutWindow := TrustPolicy{Keys: []TrustedKey{{PEM: "key-a"}}}
+
+	if trustPolicyHash(withWindow) == trustPolicyHash(withoutWindow) {
+		t.Errorf("trustPolicyHash should differ once a key's rotation window is set")
+	}
+}
+
+func TestKeylessPolicyHashChangesWithIdentities(t *testing.T) {
+	a := KeylessPolicy{Identities: []Identity{{CertOIDCIssuer: "https://issuer-a", CertIdentity: "alice"}}}
+	b := KeylessPolicy{Identities: []Identity{{CertOIDCIssuer: "https://issuer-b", CertIdentity: "alice"}}}
+
+	if keylessPolicyHash(a) == keylessPolicyHash(b) {
+		t.Errorf("keylessPolicyHash should differ for different identities")
+	}
+}
+
+func TestVerifyCacheRoundTrip(t *testing.T) {
+	SetVerifyCacheOptions(CacheOptions{Size: 10, TTL: time.Minute})
+
+	entry := verifyCacheEntry{verified: true, signer: "deadbeef"}
+	storeVerifyCache("some-key", entry)
+
+	got, ok := lookupVerifyCache("some-key")
+	if !ok {
+		t.Fatalf("expected cache hit for a just-stored key")
+	}
+	if got.verified != entry.verified || got.signer != entry.signer {
+		t.Errorf("lookupVerifyCache = %+v, want %+v", got, entry)
+	}
+
+	if _, ok := lookupVerifyCache("missing-key"); ok {
+		t.Errorf("expected cache miss for a key that was never stored")
+	}
+}
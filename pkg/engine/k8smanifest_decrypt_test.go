@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"bytes"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestDecryptEnvelopePassesThroughUnencryptedManifest(t *testing.T) {
+	raw := []byte("apiVersion: v1\nkind: ConfigMap\n")
+	got, err := decryptEnvelope(raw, DecryptionPolicy{})
+	if err != nil {
+		t.Fatalf("decryptEnvelope returned error for plaintext input: %v", err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("decryptEnvelope altered plaintext input: got %q, want %q", got, raw)
+	}
+}
+
+func TestDecryptEnvelopeOpensAgeEnvelope(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+
+	plaintext := []byte("apiVersion: v1\nkind: Secret\n")
+	var encrypted bytes.Buffer
+	w, err := age.Encrypt(&encrypted, identity.Recipient())
+	if err != nil {
+		t.Fatalf("age.Encrypt setup failed: %v", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatalf("failed to write plaintext to age writer: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close age writer: %v", err)
+	}
+
+	policy := DecryptionPolicy{Keys: []DecryptionKey{{AgeIdentity: identity.String()}}}
+	got, err := decryptEnvelope(encrypted.Bytes(), policy)
+	if err != nil {
+		t.Fatalf("decryptEnvelope failed to open age envelope: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("decryptEnvelope = %q, want %q", got, plaintext)
+	}
+}
+
+func TestDecryptEnvelopeRejectsAgeEnvelopeWithWrongKey(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	wrongIdentity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+
+	var encrypted bytes.Buffer
+	w, err := age.Encrypt(&encrypted, identity.Recipient())
+	if err != nil {
+		t.Fatalf("age.Encrypt setup failed: %v", err)
+	}
+	if _, err := w.Write([]byte("secret manifest")); err != nil {
+		t.Fatalf("failed to write plaintext to age writer: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close age writer: %v", err)
+	}
+
+	policy := DecryptionPolicy{Keys: []DecryptionKey{{AgeIdentity: wrongIdentity.String()}}}
+	if _, err := decryptEnvelope(encrypted.Bytes(), policy); err == nil {
+		t.Errorf("decryptEnvelope succeeded with a key that doesn't match the envelope")
+	}
+}
+
+func TestLooksLikeJWE(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  []byte
+		want bool
+	}{
+		{name: "five dot-separated segments", raw: []byte("a.b.c.d.e"), want: true},
+		{name: "too few segments", raw: []byte("a.b.c"), want: false},
+		{name: "contains whitespace", raw: []byte("a.b.c.d e"), want: false},
+		{name: "plain yaml", raw: []byte("apiVersion: v1\nkind: ConfigMap\n"), want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := looksLikeJWE(tc.raw); got != tc.want {
+				t.Errorf("looksLikeJWE(%q) = %v, want %v", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
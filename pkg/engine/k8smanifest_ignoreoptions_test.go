@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseResourceIgnoreOptions(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation map[string]string
+		want       resourceIgnoreOptions
+	}{
+		{
+			name:       "no annotations",
+			annotation: map[string]string{},
+			want:       resourceIgnoreOptions{},
+		},
+		{
+			name: "ignoreFields is parsed and trimmed",
+			annotation: map[string]string{
+				ManifestAnnotationKeyDomain + "ignoreFields": "spec.replicas, metadata.labels ,,spec.template",
+			},
+			want: resourceIgnoreOptions{
+				ignoreFields: []string{"spec.replicas", "metadata.labels", "spec.template"},
+			},
+		},
+		{
+			name: "IgnoreExtraneous appends serverSideInjectedFields",
+			annotation: map[string]string{
+				ManifestAnnotationKeyDomain + "compareOptions": "IgnoreExtraneous",
+			},
+			want: resourceIgnoreOptions{
+				ignoreFields: serverSideInjectedFields,
+			},
+		},
+		{
+			name: "IgnoreDryRunCreate sets skipDryRunCreate",
+			annotation: map[string]string{
+				ManifestAnnotationKeyDomain + "compareOptions": "IgnoreDryRunCreate",
+			},
+			want: resourceIgnoreOptions{skipDryRunCreate: true},
+		},
+		{
+			name: "IgnoreDryRunApply sets skipDryRunApply",
+			annotation: map[string]string{
+				ManifestAnnotationKeyDomain + "compareOptions": "IgnoreDryRunApply",
+			},
+			want: resourceIgnoreOptions{skipDryRunApply: true},
+		},
+		{
+			name: "multiple compareOptions combine",
+			annotation: map[string]string{
+				ManifestAnnotationKeyDomain + "compareOptions": "IgnoreDryRunCreate,IgnoreDryRunApply",
+			},
+			want: resourceIgnoreOptions{skipDryRunCreate: true, skipDryRunApply: true},
+		},
+		{
+			name: "unknown compareOptions value is ignored",
+			annotation: map[string]string{
+				ManifestAnnotationKeyDomain + "compareOptions": "SomeFutureOption",
+			},
+			want: resourceIgnoreOptions{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseResourceIgnoreOptions(tc.annotation)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseResourceIgnoreOptions(%v) = %+v, want %+v", tc.annotation, got, tc.want)
+			}
+		})
+	}
+}
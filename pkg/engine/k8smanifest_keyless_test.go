@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func mustSelfSignedCert(t *testing.T, tmpl *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert, priv
+}
+
+func mustSignedCert(t *testing.T, tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &priv.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+// fulcioOIDCIssuerOID is the OID Fulcio embeds the OIDC issuer under.
+var fulcioOIDCIssuerOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+func TestCertIdentityPrefersEmailThenURIThenCommonName(t *testing.T) {
+	withEmail, _ := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		Subject:        pkix.Name{CommonName: "fallback"},
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(time.Hour),
+		EmailAddresses: []string{"signer@example.com"},
+	})
+	if got := certIdentity(withEmail); got != "signer@example.com" {
+		t.Errorf("certIdentity = %q, want email address", got)
+	}
+
+	u, _ := url.Parse("https://github.com/org/repo/.github/workflows/ci.yml@refs/heads/main")
+	withURI, _ := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "fallback"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{u},
+	})
+	if got := certIdentity(withURI); got != u.String() {
+		t.Errorf("certIdentity = %q, want URI", got)
+	}
+
+	withNeither, _ := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "fallback-name"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	})
+	if got := certIdentity(withNeither); got != "fallback-name" {
+		t.Errorf("certIdentity = %q, want CommonName fallback", got)
+	}
+}
+
+func TestCertOIDCIssuer(t *testing.T) {
+	withIssuer, _ := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{
+			{Id: fulcioOIDCIssuerOID, Value: []byte("https://accounts.example.com")},
+		},
+	})
+	if got := certOIDCIssuer(withIssuer); got != "https://accounts.example.com" {
+		t.Errorf("certOIDCIssuer = %q, want the embedded issuer", got)
+	}
+
+	withoutIssuer, _ := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	})
+	if got := certOIDCIssuer(withoutIssuer); got != "" {
+		t.Errorf("certOIDCIssuer = %q, want empty string when the extension is absent", got)
+	}
+}
+
+func TestMatchesAnyIdentity(t *testing.T) {
+	cert, _ := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		Subject:        pkix.Name{CommonName: "fallback"},
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(time.Hour),
+		EmailAddresses: []string{"signer@example.com"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: fulcioOIDCIssuerOID, Value: []byte("https://accounts.example.com")},
+		},
+	})
+	issuer := certOIDCIssuer(cert)
+
+	cases := []struct {
+		name       string
+		identities []Identity
+		want       bool
+	}{
+		{
+			name:       "exact subject and issuer match",
+			identities: []Identity{{CertIdentity: "signer@example.com", CertOIDCIssuer: "https://accounts.example.com"}},
+			want:       true,
+		},
+		{
+			name:       "subject mismatch",
+			identities: []Identity{{CertIdentity: "someone-else@example.com"}},
+			want:       false,
+		},
+		{
+			name:       "issuer mismatch",
+			identities: []Identity{{CertOIDCIssuer: "https://issuer.other"}},
+			want:       false,
+		},
+		{
+			name:       "subject regexp match",
+			identities: []Identity{{CertIdentityRegExp: "^signer@.*"}},
+			want:       true,
+		},
+		{
+			name:       "issuer regexp mismatch",
+			identities: []Identity{{CertOIDCIssuerRegExp: "^https://other.*"}},
+			want:       false,
+		},
+		{
+			name:       "no identities configured",
+			identities: nil,
+			want:       false,
+		},
+		{
+			name: "no match among several identities",
+			identities: []Identity{
+				{CertIdentity: "someone-else@example.com"},
+				{CertOIDCIssuer: "https://issuer.other"},
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := matchesAnyIdentity(cert, issuer, tc.identities); got != tc.want {
+				t.Errorf("matchesAnyIdentity() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyFulcioCertChainValidatesAsOfSigningTime(t *testing.T) {
+	root, rootKey := mustSelfSignedCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test fulcio root"},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+
+	signingTime := time.Now().Add(-2 * time.Hour)
+	leaf := mustSignedCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "signer"},
+		// Fulcio leaf certs are only valid for the ~10 minute window around the signing time.
+		NotBefore:   signingTime.Add(-5 * time.Minute),
+		NotAfter:    signingTime.Add(5 * time.Minute),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, root, rootKey)
+
+	if err := verifyFulcioCertChain(leaf, roots, intermediates, signingTime); err != nil {
+		t.Errorf("verifyFulcioCertChain failed to verify as of the signing time: %v", err)
+	}
+
+	if err := verifyFulcioCertChain(leaf, roots, intermediates, time.Now()); err == nil {
+		t.Errorf("verifyFulcioCertChain should reject a short-lived leaf cert verified as of the current time, long after it expired")
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"archive/tar"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	lru "github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const imageRefAnnotationKeySuffix = "imageRef"
+
+// imageCacheSize bounds the number of distinct image references kept in imageCache at once -
+// digest-pinned refs change on every release, so without a cap this would grow forever in a
+// long-running admission controller.
+const imageCacheSize = 256
+
+// imageCacheTTL bounds how long a manifest/signature pair pulled from a registry is reused
+// before the registry is hit again for the same image reference.
+const imageCacheTTL = 5 * time.Minute
+
+// registryBundle is the manifest/signature pair extracted from an OCI-hosted signature bundle.
+type registryBundle struct {
+	manifest  []byte
+	signature []byte
+}
+
+var imageCache = lru.NewLRU[string, registryBundle](imageCacheSize, nil, imageCacheTTL)
+
+// ImagePullSecretsGetter resolves the imagePullSecrets-style credentials Kyverno has been
+// configured with, in the keychain format go-containerregistry expects. Kyverno's config
+// controller supplies this once at startup.
+type ImagePullSecretsGetter func() (authn.Keychain, error)
+
+// fetchManifestBundleFromImage pulls the OCI image referenced by imageRef (as recorded in the
+// cosign.sigstore.dev/imageRef annotation) and returns the signed YAML manifest and signature
+// embedded in its layers. Results are cached for imageCacheTTL so repeated admission reviews
+// for the same image reference don't repull the registry on every request.
+func fetchManifestBundleFromImage(imageRef string, getKeychain ImagePullSecretsGetter) ([]byte, []byte, error) {
+	if entry, ok := imageCache.Get(imageRef); ok {
+		return entry.manifest, entry.signature, nil
+	}
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid image reference %q: %v", imageRef, err)
+	}
+
+	var opts []crane.Option
+	if getKeychain != nil {
+		keychain, err := getKeychain()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve imagePullSecrets for %q: %v", imageRef, err)
+		}
+		if keychain != nil {
+			opts = append(opts, crane.WithAuthFromKeychain(keychain))
+		}
+	}
+
+	img, err := crane.Pull(ref.String(), opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pull manifest image %q: %v", imageRef, err)
+	}
+
+	manifest, signature, err := extractManifestBundle(img)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imageCache.Add(imageRef, registryBundle{manifest: manifest, signature: signature})
+
+	return manifest, signature, nil
+}
+
+// extractManifestBundle walks the image's layers looking for the well-known manifest and
+// signature files Kyverno expects cosign-sigstore manifest images to carry.
+func extractManifestBundle(img v1.Image) ([]byte, []byte, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read image layers: %v", err)
+	}
+
+	var manifest, signature []byte
+	for _, layer := range layers {
+		rc, err := layer.Uncompressed()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress image layer: %v", err)
+		}
+		tr := tar.NewReader(rc)
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				break
+			}
+			switch hdr.Name {
+			case "manifest.yaml":
+				manifest, err = ioutil.ReadAll(tr)
+				if err != nil {
+					rc.Close()
+					return nil, nil, fmt.Errorf("failed to read manifest.yaml from image layer: %v", err)
+				}
+			case "signature.sig":
+				signature, err = ioutil.ReadAll(tr)
+				if err != nil {
+					rc.Close()
+					return nil, nil, fmt.Errorf("failed to read signature.sig from image layer: %v", err)
+				}
+			}
+		}
+		rc.Close()
+	}
+
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("no manifest.yaml found in any layer of the manifest image")
+	}
+	if signature == nil {
+		return nil, nil, fmt.Errorf("no signature.sig found in any layer of the manifest image")
+	}
+	return manifest, signature, nil
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2/expirable"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+func buildTestImage(t *testing.T, files map[string]string) v1.Image {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header for %q: %v", name, err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar content for %q: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+
+	tarBytes := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(tarBytes)), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to build test layer: %v", err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatalf("failed to build test image: %v", err)
+	}
+	return img
+}
+
+func TestExtractManifestBundleFindsManifestAndSignature(t *testing.T) {
+	img := buildTestImage(t, map[string]string{
+		"manifest.yaml": "apiVersion: v1\nkind: ConfigMap\n",
+		"signature.sig": "base64-signature",
+		"ignored.txt":   "not relevant",
+	})
+
+	manifest, signature, err := extractManifestBundle(img)
+	if err != nil {
+		t.Fatalf("extractManifestBundle returned error: %v", err)
+	}
+	if string(manifest) != "apiVersion: v1\nkind: ConfigMap\n" {
+		t.Errorf("manifest = %q, want the contents of manifest.yaml", manifest)
+	}
+	if string(signature) != "base64-signature" {
+		t.Errorf("signature = %q, want the contents of signature.sig", signature)
+	}
+}
+
+func TestExtractManifestBundleMissingManifest(t *testing.T) {
+	img := buildTestImage(t, map[string]string{
+		"signature.sig": "base64-signature",
+	})
+
+	if _, _, err := extractManifestBundle(img); err == nil {
+		t.Errorf("expected an error when manifest.yaml is absent from every layer")
+	}
+}
+
+func TestImageCacheEvictsAfterTTL(t *testing.T) {
+	previous := imageCache
+	defer func() { imageCache = previous }()
+
+	imageCache = lru.NewLRU[string, registryBundle](imageCacheSize, nil, 10*time.Millisecond)
+	imageCache.Add("example.com/manifests:v1", registryBundle{manifest: []byte("m"), signature: []byte("s")})
+
+	if _, ok := imageCache.Get("example.com/manifests:v1"); !ok {
+		t.Fatalf("expected a cache hit immediately after Add")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if _, ok := imageCache.Get("example.com/manifests:v1"); ok {
+		t.Errorf("expected the entry to have expired after its TTL")
+	}
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func mustGenerateTrustedKey(t *testing.T) (TrustedKey, *ecdsa.PrivateKey, string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	fp, err := keyFingerprint(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to fingerprint public key: %v", err)
+	}
+	return TrustedKey{PEM: string(pemBytes)}, priv, fp
+}
+
+func TestSelectTrustedSignerMatchesSigningKey(t *testing.T) {
+	key, priv, fp := mustGenerateTrustedKey(t)
+	digest := sha256.Sum256([]byte("manifest bytes"))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign digest: %v", err)
+	}
+
+	trustPolicy := TrustPolicy{Keys: []TrustedKey{key}}
+	signer, hasRotationWindow, err := selectTrustedSigner(trustPolicy, nil, digest, sig, time.Now())
+	if err != nil {
+		t.Fatalf("selectTrustedSigner returned error: %v", err)
+	}
+	if signer != fp {
+		t.Errorf("signer = %q, want %q", signer, fp)
+	}
+	if hasRotationWindow {
+		t.Errorf("hasRotationWindow = true for a key with no NotBefore/NotAfter")
+	}
+}
+
+func TestSelectTrustedSignerRejectsRevokedKey(t *testing.T) {
+	key, priv, fp := mustGenerateTrustedKey(t)
+	digest := sha256.Sum256([]byte("manifest bytes"))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign digest: %v", err)
+	}
+
+	trustPolicy := TrustPolicy{Keys: []TrustedKey{key}}
+	revoked := map[string]bool{fp: true}
+	signer, _, err := selectTrustedSigner(trustPolicy, revoked, digest, sig, time.Now())
+	if err != nil {
+		t.Fatalf("selectTrustedSigner returned error: %v", err)
+	}
+	if signer != "" {
+		t.Errorf("signer = %q, want \"\" for a revoked key", signer)
+	}
+}
+
+func TestSelectTrustedSignerRotationWindow(t *testing.T) {
+	key, priv, fp := mustGenerateTrustedKey(t)
+	digest := sha256.Sum256([]byte("manifest bytes"))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign digest: %v", err)
+	}
+
+	now := time.Now()
+	notYetValid := now.Add(time.Hour)
+	expired := now.Add(-time.Hour)
+
+	cases := []struct {
+		name       string
+		notBefore  *time.Time
+		notAfter   *time.Time
+		wantSigner string
+	}{
+		{name: "before NotBefore", notBefore: &notYetValid, wantSigner: ""},
+		{name: "after NotAfter", notAfter: &expired, wantSigner: ""},
+		{name: "within window", notBefore: &expired, notAfter: &notYetValid, wantSigner: fp},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			windowed := key
+			windowed.NotBefore = tc.notBefore
+			windowed.NotAfter = tc.notAfter
+
+			trustPolicy := TrustPolicy{Keys: []TrustedKey{windowed}}
+			signer, hasRotationWindow, err := selectTrustedSigner(trustPolicy, nil, digest, sig, now)
+			if err != nil {
+				t.Fatalf("selectTrustedSigner returned error: %v", err)
+			}
+			if signer != tc.wantSigner {
+				t.Errorf("signer = %q, want %q", signer, tc.wantSigner)
+			}
+			if !hasRotationWindow {
+				t.Errorf("hasRotationWindow = false for a key with a NotBefore/NotAfter set")
+			}
+		})
+	}
+}